@@ -23,13 +23,17 @@ import (
 	"time"
 
 	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+	"github.com/gardener/gardener-resource-manager/pkg/controller/extensionconfig"
 	"github.com/gardener/gardener-resource-manager/pkg/controller/managedresources"
 
 	"github.com/spf13/cobra"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -47,24 +51,50 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 	entryLog := log.WithName("entrypoint")
 
 	var (
-		leaderElection          bool
-		leaderElectionNamespace string
-		syncPeriod              time.Duration
-		targetKubeconfigPath    string
-		maxConcurrentWorkers    int
-		namespace               string
+		leaderElection              bool
+		leaderElectionNamespace     string
+		leaderElectionID            string
+		leaderElectionLeaseDuration time.Duration
+		leaderElectionRenewDeadline time.Duration
+		leaderElectionRetryPeriod   time.Duration
+		leaderElectionResourceLock  string
+		syncPeriod                  time.Duration
+		targets                     = stringMapFlag{}
+		targetBootstrap             = stringMapFlag{}
+		targetKubeconfigDir         string
+		targetAccessTokenDir        string
+		targetTokenExpiration       time.Duration
+		maxConcurrentWorkers        int
+		healthSyncPeriod            time.Duration
+		maxConcurrentHealthWorkers  int
+		namespace                   string
 	)
 
 	cmd := &cobra.Command{
 		Use: "gardener-resource-manager",
 
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := validateLeaderElectionFlags(leaderElectionLeaseDuration, leaderElectionRenewDeadline, leaderElectionRetryPeriod); err != nil {
+				entryLog.Error(err, "invalid leader election flags")
+				os.Exit(1)
+			}
+
+			resourceLock, err := leaderElectionResourceLockName(leaderElectionResourceLock)
+			if err != nil {
+				entryLog.Error(err, "invalid leader election resource lock")
+				os.Exit(1)
+			}
+
 			mgr, err := manager.New(config.GetConfigOrDie(), manager.Options{
-				LeaderElection:          leaderElection,
-				LeaderElectionID:        "gardener-resource-manager",
-				LeaderElectionNamespace: leaderElectionNamespace,
-				SyncPeriod:              &syncPeriod,
-				Namespace:               namespace,
+				LeaderElection:             leaderElection,
+				LeaderElectionID:           leaderElectionID,
+				LeaderElectionNamespace:    leaderElectionNamespace,
+				LeaderElectionResourceLock: resourceLock,
+				LeaseDuration:              &leaderElectionLeaseDuration,
+				RenewDeadline:              &leaderElectionRenewDeadline,
+				RetryPeriod:                &leaderElectionRetryPeriod,
+				SyncPeriod:                 &syncPeriod,
+				Namespace:                  namespace,
 			})
 			if err != nil {
 				entryLog.Error(err, "could not instantiate manager")
@@ -73,12 +103,19 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 
 			utilruntime.Must(resourcesv1alpha1.AddToScheme(mgr.GetScheme()))
 
-			targetClient, err := getTargetClient(targetKubeconfigPath)
+			targetRegistry, err := buildTargets(ctx, mgr, targets, targetBootstrap, targetAccessTokenDir, targetTokenExpiration)
 			if err != nil {
-				entryLog.Error(err, "unable to create client for target cluster")
+				entryLog.Error(err, "unable to create clients for target clusters")
 				os.Exit(1)
 			}
 
+			if len(targetKubeconfigDir) > 0 {
+				if err := watchTargetKubeconfigDir(ctx, mgr, targetRegistry, targetKubeconfigDir, targetAccessTokenDir, targetTokenExpiration); err != nil {
+					entryLog.Error(err, "unable to watch target kubeconfig directory")
+					os.Exit(1)
+				}
+			}
+
 			c, err := controller.New("resource-controller", mgr, controller.Options{
 				MaxConcurrentReconciles: maxConcurrentWorkers,
 				Reconciler: managedresources.NewReconciler(
@@ -86,7 +123,8 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 					log.WithName("reconciler"),
 					mgr.GetScheme(),
 					mgr.GetClient(),
-					targetClient,
+					targetRegistry,
+					managedresources.NewExtensionInvoker(mgr.GetClient()),
 				),
 			})
 			if err != nil {
@@ -110,6 +148,46 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 				os.Exit(1)
 			}
 
+			healthController, err := controller.New("resource-health-controller", mgr, controller.Options{
+				MaxConcurrentReconciles: maxConcurrentHealthWorkers,
+				Reconciler: managedresources.NewHealthReconciler(
+					ctx,
+					log.WithName("health-reconciler"),
+					mgr.GetScheme(),
+					mgr.GetClient(),
+					targetRegistry,
+					healthSyncPeriod,
+				),
+			})
+			if err != nil {
+				entryLog.Error(err, "unable to set up health controller")
+				os.Exit(1)
+			}
+
+			if err := healthController.Watch(
+				&source.Kind{Type: &resourcesv1alpha1.ManagedResource{}},
+				&handler.EnqueueRequestForObject{},
+			); err != nil {
+				entryLog.Error(err, "unable to watch ManagedResources in health controller")
+				os.Exit(1)
+			}
+
+			extensionConfigController, err := controller.New("extensionconfig-controller", mgr, controller.Options{
+				Reconciler: extensionconfig.NewReconciler(ctx, log.WithName("extensionconfig-reconciler"), mgr.GetClient()),
+			})
+			if err != nil {
+				entryLog.Error(err, "unable to set up extensionconfig controller")
+				os.Exit(1)
+			}
+
+			if err := extensionConfigController.Watch(
+				&source.Kind{Type: &resourcesv1alpha1.ExtensionConfig{}},
+				&handler.EnqueueRequestForObject{},
+			); err != nil {
+				entryLog.Error(err, "unable to watch ExtensionConfigs")
+				os.Exit(1)
+			}
+
 			entryLog.Info("Managed namespace: " + namespace)
 			entryLog.Info("Sync period: " + syncPeriod.String())
 
@@ -122,15 +200,74 @@ func NewControllerManagerCommand(ctx context.Context) *cobra.Command {
 
 	cmd.Flags().BoolVar(&leaderElection, "leader-election", true, "enable or disable leader election")
 	cmd.Flags().StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "namespace for leader election")
+	cmd.Flags().StringVar(&leaderElectionID, "leader-election-id", "gardener-resource-manager", "identity of the leader election lock held by this instance")
+	cmd.Flags().DurationVar(&leaderElectionLeaseDuration, "leader-election-lease-duration", 15*time.Second, "duration non-leader candidates wait before forcing acquisition of leadership")
+	cmd.Flags().DurationVar(&leaderElectionRenewDeadline, "leader-election-renew-deadline", 10*time.Second, "duration the acting leader retries refreshing leadership before giving up")
+	cmd.Flags().DurationVar(&leaderElectionRetryPeriod, "leader-election-retry-period", 2*time.Second, "duration clients should wait between tries of actions")
+	cmd.Flags().StringVar(&leaderElectionResourceLock, "leader-election-resource-lock", resourcelock.LeasesResourceLock, fmt.Sprintf("which resource type to use for leader election (one of %s, %s, %s)", resourcelock.LeasesResourceLock, resourcelock.ConfigMapsLeasesResourceLock, resourcelock.EndpointsLeasesResourceLock))
 	cmd.Flags().DurationVar(&syncPeriod, "sync-period", time.Minute, "duration how often existing resources should be synced")
-	cmd.Flags().StringVar(&targetKubeconfigPath, "target-kubeconfig", "", "path to the kubeconfig for the target cluster")
+	cmd.Flags().Var(targets, "target", fmt.Sprintf("registers a target cluster ManagedResources can address via spec.targetRef.name, given as <name>=<path-to-kubeconfig>; may be given multiple times; the name %q is reserved for the cluster the resource-manager itself runs in", managedresources.InClusterTargetName))
+	cmd.Flags().Var(targetBootstrap, "target-bootstrap", "overrides bootstrap-kubeconfig auto-detection for a target registered via --target, given as <name>=<path-to-bootstrap-kubeconfig>; may be given multiple times")
+	cmd.Flags().StringVar(&targetKubeconfigDir, "target-kubeconfig-dir", "", "path to a directory containing one kubeconfig file per target cluster, named <target>.<ext>; the directory is watched and targets are added, reloaded or removed as its contents change")
+	cmd.Flags().StringVar(&targetAccessTokenDir, "target-access-token-dir", filepath.Dir(defaultAccessTokenFile), "directory under which the rotating access token obtained for a bootstrapped target is written, in a subdirectory named after the target")
+	cmd.Flags().DurationVar(&targetTokenExpiration, "target-token-expiration", time.Hour, "requested validity duration of a target cluster access token; it is renewed before expiry")
 	cmd.Flags().IntVar(&maxConcurrentWorkers, "max-concurrent-workers", 10, "number of worker threads for concurrent reconciliation of resources")
+	cmd.Flags().DurationVar(&healthSyncPeriod, "health-sync-period", 30*time.Second, "duration how often the health of applied resources should be checked")
+	cmd.Flags().IntVar(&maxConcurrentHealthWorkers, "max-concurrent-health-workers", 10, "number of worker threads for concurrent health checks of applied resources")
 	cmd.Flags().StringVar(&namespace, "namespace", "", "namespace in which the ManagedResources should be observed (defaults to all namespaces)")
 
 	return cmd
 }
 
-func getTargetConfig(kubeconfigPath string) (*rest.Config, error) {
+// validateLeaderElectionFlags checks the relationships the leader election package requires
+// between the lease duration, renew deadline and retry period.
+func validateLeaderElectionFlags(leaseDuration, renewDeadline, retryPeriod time.Duration) error {
+	if renewDeadline >= leaseDuration {
+		return fmt.Errorf("--leader-election-renew-deadline (%s) must be less than --leader-election-lease-duration (%s)", renewDeadline, leaseDuration)
+	}
+	if retryPeriod >= renewDeadline {
+		return fmt.Errorf("--leader-election-retry-period (%s) must be less than --leader-election-renew-deadline (%s)", retryPeriod, renewDeadline)
+	}
+	return nil
+}
+
+// leaderElectionResourceLockName validates that resourceLock is one of the resource lock types
+// supported by client-go's leaderelection package.
+func leaderElectionResourceLockName(resourceLock string) (string, error) {
+	switch resourceLock {
+	case resourcelock.LeasesResourceLock, resourcelock.ConfigMapsLeasesResourceLock, resourcelock.EndpointsLeasesResourceLock:
+		return resourceLock, nil
+	default:
+		return "", fmt.Errorf("unknown --leader-election-resource-lock %q", resourceLock)
+	}
+}
+
+// getTargetConfig resolves the rest.Config used to talk to the target cluster. If a bootstrap
+// kubeconfig is given explicitly via bootstrapKubeconfigPath, or kubeconfigPath itself turns out
+// to authenticate with a bootstrap token, it is exchanged for a rotating ServiceAccount access
+// token instead of being used directly.
+func getTargetConfig(ctx context.Context, kubeconfigPath, bootstrapKubeconfigPath, accessTokenFile string, tokenExpiration time.Duration) (*rest.Config, error) {
+	bootstrapPath := bootstrapKubeconfigPath
+	if len(bootstrapPath) == 0 && len(kubeconfigPath) > 0 {
+		isBootstrap, err := isBootstrapKubeconfig(kubeconfigPath)
+		if err != nil {
+			return nil, err
+		}
+		if isBootstrap {
+			bootstrapPath = kubeconfigPath
+		}
+	}
+
+	if len(bootstrapPath) > 0 {
+		return bootstrapTargetConfig(ctx, bootstrapPath, accessTokenFile, tokenExpiration)
+	}
+
+	return loadTargetConfig(kubeconfigPath)
+}
+
+// loadTargetConfig resolves a rest.Config for a kubeconfig that already carries a long-lived
+// credential, falling back to $KUBECONFIG, in-cluster config and the default kubeconfig location.
+func loadTargetConfig(kubeconfigPath string) (*rest.Config, error) {
 	if len(kubeconfigPath) > 0 {
 		return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
 	}
@@ -148,8 +285,8 @@ func getTargetConfig(kubeconfigPath string) (*rest.Config, error) {
 	return nil, fmt.Errorf("could not create config for cluster")
 }
 
-func getTargetClient(kubeconfigPath string) (client.Client, error) {
-	targetConfig, err := getTargetConfig(kubeconfigPath)
+func getTargetClient(ctx context.Context, scheme *runtime.Scheme, kubeconfigPath, bootstrapKubeconfigPath, accessTokenFile string, tokenExpiration time.Duration) (client.Client, error) {
+	targetConfig, err := getTargetConfig(ctx, kubeconfigPath, bootstrapKubeconfigPath, accessTokenFile, tokenExpiration)
 	if err != nil {
 		return nil, err
 	}
@@ -157,5 +294,36 @@ func getTargetClient(kubeconfigPath string) (client.Client, error) {
 	targetConfig.QPS = 100.0
 	targetConfig.Burst = 130
 
-	return client.New(targetConfig, client.Options{})
+	return newCachedClient(ctx, targetConfig, scheme)
+}
+
+// newCachedClient builds a client.Client backed by an informer cache for reads, the same way the
+// manager's own client is constructed, so repeated reconciliations of many ManagedResources
+// against the same target don't each hit its API server directly; writes still go straight to the
+// API server.
+func newCachedClient(ctx context.Context, targetConfig *rest.Config, scheme *runtime.Scheme) (client.Client, error) {
+	targetCache, err := cache.New(targetConfig, cache.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("could not create cache: %v", err)
+	}
+
+	go func() {
+		if err := targetCache.Start(ctx.Done()); err != nil {
+			log.Error(err, "target cache stopped")
+		}
+	}()
+	if !targetCache.WaitForCacheSync(ctx.Done()) {
+		return nil, fmt.Errorf("could not sync target cache")
+	}
+
+	directClient, err := client.New(targetConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+
+	return &client.DelegatingClient{
+		Reader:       targetCache,
+		Writer:       directClient,
+		StatusClient: directClient,
+	}, nil
 }
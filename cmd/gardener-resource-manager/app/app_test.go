@@ -0,0 +1,48 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func TestValidateLeaderElectionFlags(t *testing.T) {
+	if err := validateLeaderElectionFlags(15*time.Second, 10*time.Second, 2*time.Second); err != nil {
+		t.Errorf("expected the default durations to be valid, got error: %v", err)
+	}
+
+	if err := validateLeaderElectionFlags(10*time.Second, 10*time.Second, 2*time.Second); err == nil {
+		t.Errorf("expected an error when renew deadline is not less than lease duration")
+	}
+
+	if err := validateLeaderElectionFlags(15*time.Second, 2*time.Second, 2*time.Second); err == nil {
+		t.Errorf("expected an error when retry period is not less than renew deadline")
+	}
+}
+
+func TestLeaderElectionResourceLockName(t *testing.T) {
+	for _, valid := range []string{resourcelock.LeasesResourceLock, resourcelock.ConfigMapsLeasesResourceLock, resourcelock.EndpointsLeasesResourceLock} {
+		if got, err := leaderElectionResourceLockName(valid); err != nil || got != valid {
+			t.Errorf("expected %q to be a valid resource lock, got %q, err %v", valid, got, err)
+		}
+	}
+
+	if _, err := leaderElectionResourceLockName("bogus"); err == nil {
+		t.Errorf("expected an error for an unknown resource lock")
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// bootstrapTokenFileWellKnownPath is the path at which kubelet-style bootstrap kubeconfigs
+	// conventionally mount the bootstrap token used to authenticate before a proper credential
+	// has been issued.
+	bootstrapTokenFileWellKnownPath = "/var/run/secrets/gardener.cloud/bootstrap/token"
+
+	// bootstrapServiceAccountNamespace and bootstrapServiceAccountName identify the ServiceAccount
+	// the bootstrap token is exchanged for via the TokenRequest API.
+	bootstrapServiceAccountNamespace = "kube-system"
+	bootstrapServiceAccountName      = "gardener-resource-manager"
+
+	// accessTokenRenewalFraction is the fraction of the token's remaining lifetime after which it
+	// is renewed, e.g. 0.8 means the token is renewed once 80% of its lifetime has elapsed.
+	accessTokenRenewalFraction = 0.8
+
+	defaultAccessTokenFile = "/var/run/secrets/gardener.cloud/target/token"
+)
+
+// isBootstrapKubeconfig returns true if the kubeconfig at the given path should be treated as a
+// bootstrap kubeconfig, i.e. it authenticates via the well-known bootstrap token file rather than
+// a long-lived credential. A kubeconfig that merely authenticates with a static bearer token is
+// NOT considered a bootstrap kubeconfig: that is exactly the long-lived-credential case
+// loadTargetConfig is meant to handle, and routing it through the TokenRequest exchange would
+// fail against clusters that don't have the hardcoded bootstrapServiceAccountName ServiceAccount.
+// Callers that do want to bootstrap from a kubeconfig at a different path must say so explicitly
+// via --target-bootstrap.
+func isBootstrapKubeconfig(kubeconfigPath string) (bool, error) {
+	rawConfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, ok := rawConfig.Contexts[rawConfig.CurrentContext]
+	if !ok {
+		return false, nil
+	}
+
+	authInfo, ok := rawConfig.AuthInfos[ctx.AuthInfo]
+	if !ok {
+		return false, nil
+	}
+
+	return authInfo.TokenFile == bootstrapTokenFileWellKnownPath, nil
+}
+
+// bootstrapTargetConfig exchanges the bootstrap kubeconfig at bootstrapKubeconfigPath for a
+// ServiceAccount access token via the TokenRequest API, persists it to accessTokenFile, and
+// returns a rest.Config backed by that file. It also starts a background goroutine that renews
+// the token before it expires.
+func bootstrapTargetConfig(ctx context.Context, bootstrapKubeconfigPath, accessTokenFile string, tokenExpiration time.Duration) (*rest.Config, error) {
+	if len(accessTokenFile) == 0 {
+		accessTokenFile = defaultAccessTokenFile
+	}
+
+	bootstrapConfig, err := clientcmd.BuildConfigFromFlags("", bootstrapKubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load bootstrap kubeconfig %q: %v", bootstrapKubeconfigPath, err)
+	}
+
+	bootstrapClient, err := kubernetes.NewForConfig(bootstrapConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not create client for bootstrap kubeconfig: %v", err)
+	}
+
+	expiration, err := requestAndPersistAccessToken(bootstrapClient, accessTokenFile, tokenExpiration)
+	if err != nil {
+		return nil, fmt.Errorf("could not request initial access token: %v", err)
+	}
+
+	targetConfig := rest.CopyConfig(bootstrapConfig)
+	targetConfig.BearerToken = ""
+	targetConfig.BearerTokenFile = accessTokenFile
+
+	go renewAccessTokenBeforeExpiry(ctx, bootstrapClient, accessTokenFile, tokenExpiration, expiration)
+
+	return targetConfig, nil
+}
+
+// requestAndPersistAccessToken requests a fresh access token for the resource-manager's
+// ServiceAccount and atomically writes it to accessTokenFile. It returns the token's expiration
+// timestamp.
+func requestAndPersistAccessToken(c kubernetes.Interface, accessTokenFile string, tokenExpiration time.Duration) (time.Time, error) {
+	expirationSeconds := int64(tokenExpiration.Seconds())
+
+	tokenRequest, err := c.CoreV1().ServiceAccounts(bootstrapServiceAccountNamespace).CreateToken(bootstrapServiceAccountName, &authenticationv1.TokenRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: bootstrapServiceAccountNamespace,
+		},
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: &expirationSeconds,
+		},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := atomicWriteFile(accessTokenFile, []byte(tokenRequest.Status.Token)); err != nil {
+		return time.Time{}, err
+	}
+
+	return tokenRequest.Status.ExpirationTimestamp.Time, nil
+}
+
+// renewAccessTokenBeforeExpiry renews the access token at accessTokenFile once
+// accessTokenRenewalFraction of its lifetime has elapsed, and repeats indefinitely until ctx is
+// done.
+func renewAccessTokenBeforeExpiry(ctx context.Context, c kubernetes.Interface, accessTokenFile string, tokenExpiration time.Duration, expiration time.Time) {
+	entryLog := log.WithName("access-token-renewal")
+
+	for {
+		renewAt := expiration.Add(-time.Duration(float64(time.Until(expiration)) * (1 - accessTokenRenewalFraction)))
+		timer := time.NewTimer(time.Until(renewAt))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			next, err := requestAndPersistAccessToken(c, accessTokenFile, tokenExpiration)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					entryLog.Error(err, "ServiceAccount for access token renewal not found, will retry")
+				} else {
+					entryLog.Error(err, "unable to renew access token")
+				}
+				timer.Reset(time.Minute)
+				select {
+				case <-ctx.Done():
+					return
+				case <-timer.C:
+				}
+				continue
+			}
+			entryLog.Info("renewed target cluster access token", "expiration", next)
+			expiration = next
+		}
+	}
+}
+
+// atomicWriteFile writes data to path by first writing to a temporary file in the same directory
+// and then renaming it into place, so readers never observe a partially written token.
+func atomicWriteFile(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(dir, ".tmp-"+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
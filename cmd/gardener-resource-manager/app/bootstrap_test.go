@@ -0,0 +1,100 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKubeconfig(t *testing.T, dir, authInfo string) string {
+	t.Helper()
+
+	content := `
+apiVersion: v1
+kind: Config
+current-context: default
+contexts:
+- name: default
+  context:
+    cluster: default
+    user: default
+clusters:
+- name: default
+  cluster:
+    server: https://example.com
+users:
+- name: default
+  user:
+    ` + authInfo + `
+`
+
+	path := filepath.Join(dir, "kubeconfig.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("could not write kubeconfig: %v", err)
+	}
+	return path
+}
+
+func TestIsBootstrapKubeconfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		authInfo string
+		want     bool
+	}{
+		{
+			name:     "well-known bootstrap token file",
+			authInfo: "tokenFile: " + bootstrapTokenFileWellKnownPath,
+			want:     true,
+		},
+		{
+			name:     "static bearer token is a long-lived credential, not bootstrap",
+			authInfo: "token: some-long-lived-service-account-token",
+			want:     false,
+		},
+		{
+			name:     "token file at a non-bootstrap path is a long-lived credential",
+			authInfo: "tokenFile: /var/run/secrets/kubernetes.io/serviceaccount/token",
+			want:     false,
+		},
+		{
+			name:     "client certificate is a long-lived credential",
+			authInfo: "client-certificate: /tmp/cert.pem",
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "bootstrap-test")
+			if err != nil {
+				t.Fatalf("could not create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			path := writeKubeconfig(t, dir, tt.authInfo)
+
+			got, err := isBootstrapKubeconfig(path)
+			if err != nil {
+				t.Fatalf("isBootstrapKubeconfig returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isBootstrapKubeconfig() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
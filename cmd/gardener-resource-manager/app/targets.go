@@ -0,0 +1,177 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gardener/gardener-resource-manager/pkg/controller/managedresources"
+
+	"github.com/fsnotify/fsnotify"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// stringMapFlag is a pflag.Value implementing a repeatable "<name>=<value>" flag, collecting
+// every occurrence into a map keyed by name.
+type stringMapFlag map[string]string
+
+func (f stringMapFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for name, value := range f {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", name, value))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f stringMapFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return fmt.Errorf("expected <name>=<value>, got %q", value)
+	}
+	f[parts[0]] = parts[1]
+	return nil
+}
+
+func (stringMapFlag) Type() string {
+	return "name=value"
+}
+
+// buildTargets resolves a client.Client for every entry in namedTargets, plus the reserved
+// in-cluster pseudo-target backed by the manager's own (already cache-backed) client, and returns
+// them as a populated Targets registry.
+func buildTargets(ctx context.Context, mgr manager.Manager, namedTargets, targetBootstrap map[string]string, accessTokenDir string, tokenExpiration time.Duration) (*managedresources.Targets, error) {
+	targets := managedresources.NewTargets()
+	targets.Set(managedresources.InClusterTargetName, mgr.GetClient(), nil)
+
+	for name, kubeconfigPath := range namedTargets {
+		if err := addTarget(ctx, mgr, targets, name, kubeconfigPath, targetBootstrap[name], accessTokenDir, tokenExpiration); err != nil {
+			return nil, err
+		}
+	}
+
+	return targets, nil
+}
+
+// addTarget builds a cache-backed client.Client for the kubeconfig at kubeconfigPath,
+// auto-detecting (or using the explicit bootstrapKubeconfigPath override for) a bootstrap-token
+// kubeconfig, and registers it under name. The client's cache is bound to its own child of ctx, so
+// replacing or removing the target later (e.g. reloading it via watchTargetKubeconfigDir) stops its
+// informers instead of leaking them.
+func addTarget(ctx context.Context, mgr manager.Manager, targets *managedresources.Targets, name, kubeconfigPath, bootstrapKubeconfigPath, accessTokenDir string, tokenExpiration time.Duration) error {
+	if name == managedresources.InClusterTargetName {
+		return fmt.Errorf("target name %q is reserved for the in-cluster pseudo-target", name)
+	}
+
+	targetCtx, cancel := context.WithCancel(ctx)
+
+	c, err := getTargetClient(targetCtx, mgr.GetScheme(), kubeconfigPath, bootstrapKubeconfigPath, filepath.Join(accessTokenDir, name, "token"), tokenExpiration)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("unable to create client for target %q: %v", name, err)
+	}
+
+	targets.Set(name, c, cancel)
+	return nil
+}
+
+// targetNameFromFile derives a target name from a kubeconfig file's name by stripping its
+// extension, e.g. "my-shoot.yaml" becomes "my-shoot".
+func targetNameFromFile(fileName string) string {
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+}
+
+// loadTargetKubeconfigDir registers one target per regular file directly inside dir, named after
+// the file per targetNameFromFile.
+func loadTargetKubeconfigDir(ctx context.Context, mgr manager.Manager, targets *managedresources.Targets, dir, accessTokenDir string, tokenExpiration time.Duration) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("could not read target kubeconfig directory %q: %v", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := targetNameFromFile(entry.Name())
+		if err := addTarget(ctx, mgr, targets, name, filepath.Join(dir, entry.Name()), "", accessTokenDir, tokenExpiration); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watchTargetKubeconfigDir loads dir once and then keeps targets in sync with its contents for as
+// long as ctx is not done, adding or reloading a target whenever its kubeconfig file is created or
+// written, and removing it when the file is deleted or renamed away.
+func watchTargetKubeconfigDir(ctx context.Context, mgr manager.Manager, targets *managedresources.Targets, dir, accessTokenDir string, tokenExpiration time.Duration) error {
+	entryLog := log.WithName("target-kubeconfig-dir-watch")
+
+	if err := loadTargetKubeconfigDir(ctx, mgr, targets, dir, accessTokenDir, tokenExpiration); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("could not create watcher for target kubeconfig directory %q: %v", dir, err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("could not watch target kubeconfig directory %q: %v", dir, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := targetNameFromFile(filepath.Base(event.Name))
+
+				switch {
+				case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+					targets.Remove(name)
+					entryLog.Info("removed target", "target", name)
+
+				case event.Op&(fsnotify.Create|fsnotify.Write) != 0:
+					if err := addTarget(ctx, mgr, targets, name, event.Name, "", accessTokenDir, tokenExpiration); err != nil {
+						entryLog.Error(err, "unable to (re)load target from kubeconfig directory", "target", name)
+						continue
+					}
+					entryLog.Info("loaded target", "target", name)
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				entryLog.Error(err, "error watching target kubeconfig directory")
+			}
+		}
+	}()
+
+	return nil
+}
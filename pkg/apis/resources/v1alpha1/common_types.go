@@ -0,0 +1,69 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a Condition.
+type ConditionType string
+
+// ConditionStatus is the status of a Condition.
+type ConditionStatus string
+
+const (
+	// ConditionTrue means the condition holds.
+	ConditionTrue ConditionStatus = "True"
+	// ConditionFalse means the condition does not hold.
+	ConditionFalse ConditionStatus = "False"
+	// ConditionUnknown means the condition could not be evaluated.
+	ConditionUnknown ConditionStatus = "Unknown"
+	// ConditionProgressing means the condition has not been evaluated yet and is still being
+	// determined.
+	ConditionProgressing ConditionStatus = "Progressing"
+)
+
+// Condition is a status condition on a resources.gardener.cloud resource.
+type Condition struct {
+	// Type is the type of the condition.
+	Type ConditionType `json:"type" protobuf:"bytes,1,opt,name=type,casttype=ConditionType"`
+	// Status is the status of the condition.
+	Status ConditionStatus `json:"status" protobuf:"bytes,2,opt,name=status,casttype=ConditionStatus"`
+	// LastTransitionTime is the last time the condition's status changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime" protobuf:"bytes,3,opt,name=lastTransitionTime"`
+	// LastUpdateTime is the last time this condition was updated, whether or not its status
+	// changed.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime" protobuf:"bytes,4,opt,name=lastUpdateTime"`
+	// Reason is a machine-readable reason for the condition's status.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,5,opt,name=reason"`
+	// Message is a human-readable message giving details about the condition's status.
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,6,opt,name=message"`
+}
+
+// ObjectReference identifies an object that was applied to a target cluster.
+type ObjectReference struct {
+	// APIVersion is the apiVersion of the referenced object.
+	APIVersion string `json:"apiVersion" protobuf:"bytes,1,opt,name=apiVersion"`
+	// Kind is the kind of the referenced object.
+	Kind string `json:"kind" protobuf:"bytes,2,opt,name=kind"`
+	// Namespace is the namespace of the referenced object, empty for cluster-scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty" protobuf:"bytes,3,opt,name=namespace"`
+	// Name is the name of the referenced object.
+	Name string `json:"name" protobuf:"bytes,4,opt,name=name"`
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HookType identifies a point in the ManagedResource reconciliation at which registered
+// extensions are invoked.
+type HookType string
+
+const (
+	// HookBeforeApply is invoked with the decoded object list before it is applied to the target
+	// cluster. Extensions may mutate or drop objects.
+	HookBeforeApply HookType = "BeforeApply"
+	// HookAfterApply is invoked with the object list that was actually applied to the target
+	// cluster.
+	HookAfterApply HookType = "AfterApply"
+	// HookBeforeDelete is invoked with the object list before it is deleted from the target
+	// cluster.
+	HookBeforeDelete HookType = "BeforeDelete"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ExtensionConfig registers a webhook endpoint that the resource manager calls out to at
+// well-defined points during ManagedResource reconciliation, allowing operators to plug in
+// mutation or policy logic without forking the resource manager.
+type ExtensionConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Spec contains the specification of this ExtensionConfig.
+	Spec ExtensionConfigSpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+	// Status contains the status of this ExtensionConfig.
+	// +optional
+	Status ExtensionConfigStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// ExtensionConfigSpec is the specification of an ExtensionConfig.
+type ExtensionConfigSpec struct {
+	// Webhook contains the connection details of the extension's webhook endpoint.
+	Webhook WebhookClientConfig `json:"webhook" protobuf:"bytes,1,opt,name=webhook"`
+	// Hooks lists the hook points this extension wants to be invoked at. If empty, the extension
+	// is invoked at all hook points.
+	// +optional
+	Hooks []HookType `json:"hooks,omitempty" protobuf:"bytes,2,rep,name=hooks,casttype=HookType"`
+	// NamespaceSelector restricts this extension to ManagedResources in matching namespaces. If
+	// nil, ManagedResources in all namespaces are matched.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty" protobuf:"bytes,3,opt,name=namespaceSelector"`
+	// ObjectSelector restricts this extension to objects with matching labels. If nil, all
+	// objects are matched.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty" protobuf:"bytes,4,opt,name=objectSelector"`
+	// FailurePolicy defines how unreachable or erroring extensions are handled. Defaults to Fail.
+	// +optional
+	FailurePolicy *FailurePolicyType `json:"failurePolicy,omitempty" protobuf:"bytes,5,opt,name=failurePolicy,casttype=FailurePolicyType"`
+}
+
+// FailurePolicyType defines how errors from calling an extension are handled.
+type FailurePolicyType string
+
+const (
+	// FailurePolicyFail aborts the reconciliation if the extension cannot be reached or returns
+	// an error.
+	FailurePolicyFail FailurePolicyType = "Fail"
+	// FailurePolicyIgnore drops the error and proceeds as if the extension had not been called;
+	// the error is not logged.
+	FailurePolicyIgnore FailurePolicyType = "Ignore"
+)
+
+// WebhookClientConfig contains the information to locate and trust an extension's webhook
+// endpoint.
+type WebhookClientConfig struct {
+	// URL is the HTTPS endpoint the extension is reached at, e.g.
+	// "https://my-extension.my-namespace.svc:443/hook".
+	URL string `json:"url" protobuf:"bytes,1,opt,name=url"`
+	// CABundle is the PEM-encoded CA bundle used to verify the extension's serving certificate.
+	// +optional
+	CABundle []byte `json:"caBundle,omitempty" protobuf:"bytes,2,opt,name=caBundle"`
+	// TimeoutSeconds bounds how long a single hook invocation may take. Defaults to 10.
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty" protobuf:"varint,3,opt,name=timeoutSeconds"`
+}
+
+// ExtensionConfigStatus is the status of an ExtensionConfig.
+type ExtensionConfigStatus struct {
+	// Conditions represents the latest available observations of the extension's state.
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty" protobuf:"bytes,1,rep,name=conditions"`
+	// ObservedGeneration is the most recent generation observed for this ExtensionConfig.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" protobuf:"varint,2,opt,name=observedGeneration"`
+}
+
+// ConditionTypeReachable is the condition type under which the extensionconfig controller reports
+// whether it was able to reach the extension's webhook endpoint during discovery/warmup.
+const ConditionTypeReachable ConditionType = "Reachable"
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ExtensionConfigList is a list of ExtensionConfigs.
+type ExtensionConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Items is the list of ExtensionConfigs.
+	Items []ExtensionConfig `json:"items" protobuf:"bytes,2,rep,name=items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ExtensionConfig{}, &ExtensionConfigList{})
+}
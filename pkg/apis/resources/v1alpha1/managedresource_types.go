@@ -0,0 +1,111 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ManagedResource describes a list of managed resources, collected from the Secrets it
+// references, that the resource manager applies to and keeps in sync on a target cluster.
+type ManagedResource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Spec contains the specification of this ManagedResource.
+	Spec ManagedResourceSpec `json:"spec" protobuf:"bytes,2,opt,name=spec"`
+	// Status contains the status of this ManagedResource.
+	// +optional
+	Status ManagedResourceStatus `json:"status,omitempty" protobuf:"bytes,3,opt,name=status"`
+}
+
+// ManagedResourceSpec is the specification of a ManagedResource.
+type ManagedResourceSpec struct {
+	// SecretRefs references the Secrets, each containing one or more serialized Kubernetes
+	// objects keyed by file name, that make up this ManagedResource.
+	SecretRefs []corev1.LocalObjectReference `json:"secretRefs" protobuf:"bytes,1,rep,name=secretRefs"`
+	// KeepObjects specifies whether the objects should be kept on the target cluster when this
+	// ManagedResource is deleted. Defaults to false.
+	// +optional
+	KeepObjects *bool `json:"keepObjects,omitempty" protobuf:"varint,2,opt,name=keepObjects"`
+	// ForceOverwriteLabels specifies whether labels on existing objects should be overwritten
+	// with the labels given in this ManagedResource. Defaults to false.
+	// +optional
+	ForceOverwriteLabels *bool `json:"forceOverwriteLabels,omitempty" protobuf:"varint,3,opt,name=forceOverwriteLabels"`
+	// ForceOverwriteAnnotations specifies whether annotations on existing objects should be
+	// overwritten with the annotations given in this ManagedResource. Defaults to false.
+	// +optional
+	ForceOverwriteAnnotations *bool `json:"forceOverwriteAnnotations,omitempty" protobuf:"varint,4,opt,name=forceOverwriteAnnotations"`
+	// TargetRef selects the target cluster the objects are applied to. If nil, the "in-cluster"
+	// pseudo-target is used, i.e. the cluster the resource manager itself runs in.
+	// +optional
+	TargetRef *TargetRef `json:"targetRef,omitempty" protobuf:"bytes,5,opt,name=targetRef"`
+}
+
+// TargetRef selects the target cluster a ManagedResource's objects are applied to, by name among
+// the targets the resource-manager process was configured with (see --target and
+// --target-kubeconfig-dir).
+type TargetRef struct {
+	// Name is the name of the target, as registered on the resource-manager process. The
+	// reserved name "in-cluster" always refers to the cluster the resource-manager itself runs
+	// in.
+	Name string `json:"name" protobuf:"bytes,1,opt,name=name"`
+}
+
+// ManagedResourceStatus is the status of a ManagedResource.
+type ManagedResourceStatus struct {
+	// Conditions represents the latest available observations of this ManagedResource's state.
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty" protobuf:"bytes,1,rep,name=conditions"`
+	// ObservedGeneration is the most recent generation observed for this ManagedResource.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty" protobuf:"varint,2,opt,name=observedGeneration"`
+	// Resources is the list of objects that have been applied to the target cluster, together
+	// with their individually observed health.
+	// +optional
+	Resources []ObjectStatus `json:"resources,omitempty" protobuf:"bytes,3,rep,name=resources"`
+}
+
+// ObjectStatus is the observed state of a single object a ManagedResource has applied to the
+// target cluster.
+type ObjectStatus struct {
+	ObjectReference `json:",inline" protobuf:"bytes,1,opt,name=objectReference"`
+	// Health is whether this object was found to be healthy the last time it was checked.
+	// +optional
+	Health ConditionStatus `json:"health,omitempty" protobuf:"bytes,2,opt,name=health,casttype=ConditionStatus"`
+	// Message gives details about Health, in particular why an object is unhealthy.
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,3,opt,name=message"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ManagedResourceList is a list of ManagedResources.
+type ManagedResourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty" protobuf:"bytes,1,opt,name=metadata"`
+
+	// Items is the list of ManagedResources.
+	Items []ManagedResource `json:"items" protobuf:"bytes,2,rep,name=items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ManagedResource{}, &ManagedResourceList{})
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conditions provides helpers for maintaining resourcesv1alpha1.Condition lists on a
+// status subresource, shared by every reconciler that reports its outcome as a condition.
+package conditions
+
+import (
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetOrInit returns the condition of the given type from conditions, or a freshly initialized one
+// with status Progressing if none exists yet.
+func GetOrInit(conditions []resourcesv1alpha1.Condition, conditionType resourcesv1alpha1.ConditionType) resourcesv1alpha1.Condition {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition
+		}
+	}
+
+	now := metav1.Now()
+	return resourcesv1alpha1.Condition{
+		Type:               conditionType,
+		Status:             resourcesv1alpha1.ConditionProgressing,
+		LastTransitionTime: now,
+		LastUpdateTime:     now,
+	}
+}
+
+// Update returns a copy of condition with the given status, reason and message, bumping
+// LastTransitionTime only if the status actually changed and always bumping LastUpdateTime.
+func Update(condition resourcesv1alpha1.Condition, status resourcesv1alpha1.ConditionStatus, reason, message string) resourcesv1alpha1.Condition {
+	now := metav1.Now()
+
+	newCondition := condition
+	newCondition.Status = status
+	newCondition.Reason = reason
+	newCondition.Message = message
+	newCondition.LastUpdateTime = now
+
+	if condition.Status != status {
+		newCondition.LastTransitionTime = now
+	}
+
+	return newCondition
+}
+
+// IsTrue returns true if conditions contains a condition of the given type with status True.
+func IsTrue(conditions []resourcesv1alpha1.Condition, conditionType resourcesv1alpha1.ConditionType) bool {
+	for _, condition := range conditions {
+		if condition.Type == conditionType {
+			return condition.Status == resourcesv1alpha1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// Set returns a copy of conditions with newCondition inserted, replacing any existing condition of
+// the same type.
+func Set(conditions []resourcesv1alpha1.Condition, newCondition resourcesv1alpha1.Condition) []resourcesv1alpha1.Condition {
+	result := make([]resourcesv1alpha1.Condition, 0, len(conditions)+1)
+
+	replaced := false
+	for _, condition := range conditions {
+		if condition.Type == newCondition.Type {
+			result = append(result, newCondition)
+			replaced = true
+			continue
+		}
+		result = append(result, condition)
+	}
+
+	if !replaced {
+		result = append(result, newCondition)
+	}
+
+	return result
+}
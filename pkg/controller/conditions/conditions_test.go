@@ -0,0 +1,86 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conditions
+
+import (
+	"testing"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+)
+
+const conditionTypeForTest resourcesv1alpha1.ConditionType = "TestCondition"
+const otherConditionTypeForTest resourcesv1alpha1.ConditionType = "OtherTestCondition"
+
+func TestGetOrInit(t *testing.T) {
+	existing := resourcesv1alpha1.Condition{Type: conditionTypeForTest, Status: resourcesv1alpha1.ConditionTrue}
+
+	if got := GetOrInit(nil, conditionTypeForTest); got.Status != resourcesv1alpha1.ConditionProgressing {
+		t.Errorf("expected a freshly initialized condition to be Progressing, got %v", got.Status)
+	}
+
+	if got := GetOrInit([]resourcesv1alpha1.Condition{existing}, conditionTypeForTest); got.Status != resourcesv1alpha1.ConditionTrue {
+		t.Errorf("expected existing condition to be returned unchanged, got %v", got.Status)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	initial := resourcesv1alpha1.Condition{Type: conditionTypeForTest, Status: resourcesv1alpha1.ConditionFalse}
+
+	sameStatus := Update(initial, resourcesv1alpha1.ConditionFalse, "StillFailing", "still failing")
+	if sameStatus.LastTransitionTime != initial.LastTransitionTime {
+		t.Errorf("LastTransitionTime must not change when status is unchanged")
+	}
+
+	changedStatus := Update(initial, resourcesv1alpha1.ConditionTrue, "NowHealthy", "now healthy")
+	if changedStatus.Status != resourcesv1alpha1.ConditionTrue {
+		t.Errorf("expected status to be updated to True, got %v", changedStatus.Status)
+	}
+	if changedStatus.LastTransitionTime == initial.LastTransitionTime {
+		t.Errorf("expected LastTransitionTime to change when status changes")
+	}
+}
+
+func TestIsTrue(t *testing.T) {
+	conditions := []resourcesv1alpha1.Condition{
+		{Type: conditionTypeForTest, Status: resourcesv1alpha1.ConditionTrue},
+		{Type: otherConditionTypeForTest, Status: resourcesv1alpha1.ConditionFalse},
+	}
+
+	if !IsTrue(conditions, conditionTypeForTest) {
+		t.Errorf("expected %s to be true", conditionTypeForTest)
+	}
+	if IsTrue(conditions, otherConditionTypeForTest) {
+		t.Errorf("expected %s to be false", otherConditionTypeForTest)
+	}
+	if IsTrue(conditions, "Unknown") {
+		t.Errorf("expected an absent condition type to be false")
+	}
+}
+
+func TestSet(t *testing.T) {
+	original := []resourcesv1alpha1.Condition{
+		{Type: conditionTypeForTest, Status: resourcesv1alpha1.ConditionFalse},
+	}
+
+	replaced := Set(original, resourcesv1alpha1.Condition{Type: conditionTypeForTest, Status: resourcesv1alpha1.ConditionTrue})
+	if len(replaced) != 1 || replaced[0].Status != resourcesv1alpha1.ConditionTrue {
+		t.Errorf("expected the existing condition to be replaced in place, got %+v", replaced)
+	}
+
+	appended := Set(original, resourcesv1alpha1.Condition{Type: otherConditionTypeForTest, Status: resourcesv1alpha1.ConditionTrue})
+	if len(appended) != 2 {
+		t.Errorf("expected a new condition type to be appended, got %+v", appended)
+	}
+}
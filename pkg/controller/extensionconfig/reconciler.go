@@ -0,0 +1,143 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extensionconfig implements a controller that discovers the webhook endpoints
+// registered via ExtensionConfig resources and periodically checks their reachability.
+package extensionconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"time"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+	"github.com/gardener/gardener-resource-manager/pkg/controller/conditions"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	defaultTimeout    = 10 * time.Second
+	warmupSyncPeriod  = 30 * time.Second
+	conditionReasonOK = "WebhookReachable"
+)
+
+type reconciler struct {
+	ctx        context.Context
+	log        logr.Logger
+	client     client.Client
+	httpClient func(caBundle []byte) (*http.Client, error)
+}
+
+// NewReconciler creates a reconciler that discovers the webhook endpoint registered by an
+// ExtensionConfig and reflects its reachability on the ExtensionConfig status.
+func NewReconciler(ctx context.Context, log logr.Logger, c client.Client) reconcile.Reconciler {
+	return &reconciler{
+		ctx:        ctx,
+		log:        log,
+		client:     c,
+		httpClient: httpClientForCABundle,
+	}
+}
+
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	extensionConfig := &resourcesv1alpha1.ExtensionConfig{}
+	if err := r.client.Get(r.ctx, request.NamespacedName, extensionConfig); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	condition := conditions.GetOrInit(extensionConfig.Status.Conditions, resourcesv1alpha1.ConditionTypeReachable)
+
+	httpClient, err := r.httpClient(extensionConfig.Spec.Webhook.CABundle)
+	if err != nil {
+		condition = failCondition(condition, "InvalidCABundle", err.Error())
+	} else if err := ping(r.ctx, httpClient, extensionConfig.Spec.Webhook); err != nil {
+		condition = failCondition(condition, "WebhookUnreachable", err.Error())
+	} else {
+		condition = okCondition(condition)
+	}
+
+	extensionConfig.Status.Conditions = conditions.Set(extensionConfig.Status.Conditions, condition)
+	extensionConfig.Status.ObservedGeneration = extensionConfig.Generation
+
+	if err := r.client.Status().Update(r.ctx, extensionConfig); err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not update status of ExtensionConfig %s: %v", request.NamespacedName, err)
+	}
+
+	return reconcile.Result{RequeueAfter: warmupSyncPeriod}, nil
+}
+
+// ping sends a lightweight request to the extension's webhook endpoint to confirm it is
+// reachable and presents a trusted certificate.
+func ping(ctx context.Context, httpClient *http.Client, webhook resourcesv1alpha1.WebhookClientConfig) error {
+	timeout := defaultTimeout
+	if webhook.TimeoutSeconds != nil {
+		timeout = time.Duration(*webhook.TimeoutSeconds) * time.Second
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, webhook.URL, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(reqCtx)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("webhook %q returned status %d", webhook.URL, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func httpClientForCABundle(caBundle []byte) (*http.Client, error) {
+	if len(caBundle) == 0 {
+		return http.DefaultClient, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("could not parse CA bundle")
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}, nil
+}
+
+func okCondition(condition resourcesv1alpha1.Condition) resourcesv1alpha1.Condition {
+	return conditions.Update(condition, resourcesv1alpha1.ConditionTrue, conditionReasonOK, "Webhook endpoint is reachable")
+}
+
+func failCondition(condition resourcesv1alpha1.Condition, reason, message string) resourcesv1alpha1.Condition {
+	return conditions.Update(condition, resourcesv1alpha1.ConditionFalse, reason, message)
+}
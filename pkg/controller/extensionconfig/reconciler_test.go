@@ -0,0 +1,46 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extensionconfig
+
+import (
+	"testing"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+)
+
+func TestOkAndFailCondition(t *testing.T) {
+	progressing := resourcesv1alpha1.Condition{Type: resourcesv1alpha1.ConditionTypeReachable, Status: resourcesv1alpha1.ConditionProgressing}
+
+	ok := okCondition(progressing)
+	if ok.Status != resourcesv1alpha1.ConditionTrue || ok.Reason != conditionReasonOK {
+		t.Errorf("expected okCondition to transition to True with reason %q, got %+v", conditionReasonOK, ok)
+	}
+	if ok.LastTransitionTime == progressing.LastTransitionTime {
+		t.Errorf("expected LastTransitionTime to change when status changes")
+	}
+
+	failed := failCondition(ok, "WebhookUnreachable", "connection refused")
+	if failed.Status != resourcesv1alpha1.ConditionFalse || failed.Reason != "WebhookUnreachable" {
+		t.Errorf("expected failCondition to transition to False, got %+v", failed)
+	}
+
+	again := failCondition(failed, "WebhookUnreachable", "still refused")
+	if again.LastTransitionTime != failed.LastTransitionTime {
+		t.Errorf("expected LastTransitionTime not to change when status stays the same")
+	}
+	if again.Message != "still refused" {
+		t.Errorf("expected the message to be refreshed even when status is unchanged")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+)
+
+const (
+	// ConditionResourcesApplied is the condition type under which the apply reconciler reports
+	// whether it was able to apply all objects of a ManagedResource to the target cluster.
+	ConditionResourcesApplied resourcesv1alpha1.ConditionType = "ResourcesApplied"
+
+	// ConditionResourcesHealthy is the condition type under which the health reconciler reports
+	// the aggregated health of the objects a ManagedResource has applied to the target cluster.
+	ConditionResourcesHealthy resourcesv1alpha1.ConditionType = "ResourcesHealthy"
+)
@@ -0,0 +1,280 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const defaultHookTimeout = 10 * time.Second
+
+// ExtensionHookRequest is the JSON payload sent to an extension's webhook endpoint.
+type ExtensionHookRequest struct {
+	// Hook identifies the point in the reconciliation this request was sent from.
+	Hook resourcesv1alpha1.HookType `json:"hook"`
+	// ManagedResource is the ManagedResource being reconciled.
+	ManagedResource resourcesv1alpha1.ManagedResource `json:"managedResource"`
+	// Objects is the decoded object list for the given hook.
+	Objects []unstructured.Unstructured `json:"objects"`
+}
+
+// ExtensionHookResponse is the JSON payload an extension may return to influence reconciliation.
+type ExtensionHookResponse struct {
+	// Objects, if set, replaces the object list passed in the request - used to mutate or drop
+	// objects.
+	Objects []unstructured.Unstructured `json:"objects,omitempty"`
+	// RetryAfterSeconds, if set, asks the reconciler to requeue instead of proceeding.
+	RetryAfterSeconds *int32 `json:"retryAfterSeconds,omitempty"`
+	// ShortCircuit, if true, stops the reconciliation at this hook.
+	ShortCircuit bool `json:"shortCircuit,omitempty"`
+	// Message is surfaced on the ManagedResource status when ShortCircuit or RetryAfterSeconds is
+	// set.
+	Message string `json:"message,omitempty"`
+}
+
+// ExtensionHookResult is the aggregated outcome of invoking all matching extensions for a hook.
+type ExtensionHookResult struct {
+	Objects      []unstructured.Unstructured
+	RetryAfter   *time.Duration
+	ShortCircuit bool
+	Message      string
+}
+
+// ExtensionInvoker discovers ExtensionConfigs registered on the source cluster and invokes their
+// webhook endpoints at the BeforeApply, AfterApply and BeforeDelete hook points.
+type ExtensionInvoker struct {
+	sourceClient client.Client
+	httpClient   *http.Client
+}
+
+// NewExtensionInvoker creates an ExtensionInvoker that looks up ExtensionConfigs via sourceClient.
+func NewExtensionInvoker(sourceClient client.Client) *ExtensionInvoker {
+	return &ExtensionInvoker{
+		sourceClient: sourceClient,
+		httpClient:   http.DefaultClient,
+	}
+}
+
+// Invoke calls every ExtensionConfig matching mr and hook, in order, feeding the output of one
+// into the next, and returns the aggregated result. If an extension's FailurePolicy is Fail (the
+// default) and it cannot be reached, Invoke returns an error; if it is Ignore, the error is
+// dropped and the objects are passed through unmodified. Invoke stops calling further extensions
+// as soon as one asks for a retry or short-circuits the reconciliation.
+func (i *ExtensionInvoker) Invoke(ctx context.Context, hook resourcesv1alpha1.HookType, mr *resourcesv1alpha1.ManagedResource, objects []unstructured.Unstructured) (*ExtensionHookResult, error) {
+	extensionConfigList := &resourcesv1alpha1.ExtensionConfigList{}
+	if err := i.sourceClient.List(ctx, nil, extensionConfigList); err != nil {
+		return nil, fmt.Errorf("could not list ExtensionConfigs: %v", err)
+	}
+
+	result := &ExtensionHookResult{Objects: objects}
+
+	for _, extensionConfig := range extensionConfigList.Items {
+		matches, err := i.matchesNamespace(ctx, extensionConfig, mr.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if !matchesHook(extensionConfig, hook) || !matches {
+			continue
+		}
+
+		objects, err := filterByObjectSelector(extensionConfig, result.Objects)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := i.call(ctx, extensionConfig, hook, mr, objects)
+		if err != nil {
+			if failurePolicy(extensionConfig) == resourcesv1alpha1.FailurePolicyIgnore {
+				continue
+			}
+			return nil, fmt.Errorf("extension %q failed for hook %s: %v", extensionConfig.Name, hook, err)
+		}
+
+		if response.Objects != nil {
+			result.Objects = mergeObjects(result.Objects, objects, response.Objects)
+		}
+		if response.RetryAfterSeconds != nil {
+			retryAfter := time.Duration(*response.RetryAfterSeconds) * time.Second
+			result.RetryAfter = &retryAfter
+			result.Message = response.Message
+			return result, nil
+		}
+		if response.ShortCircuit {
+			result.ShortCircuit = true
+			result.Message = response.Message
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+func (i *ExtensionInvoker) call(ctx context.Context, extensionConfig resourcesv1alpha1.ExtensionConfig, hook resourcesv1alpha1.HookType, mr *resourcesv1alpha1.ManagedResource, objects []unstructured.Unstructured) (*ExtensionHookResponse, error) {
+	timeout := defaultHookTimeout
+	if extensionConfig.Spec.Webhook.TimeoutSeconds != nil {
+		timeout = time.Duration(*extensionConfig.Spec.Webhook.TimeoutSeconds) * time.Second
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(ExtensionHookRequest{Hook: hook, ManagedResource: *mr, Objects: objects})
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := i.httpClient
+	if len(extensionConfig.Spec.Webhook.CABundle) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(extensionConfig.Spec.Webhook.CABundle) {
+			return nil, fmt.Errorf("could not parse CA bundle of extension %q", extensionConfig.Name)
+		}
+		httpClient = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, extensionConfig.Spec.Webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(reqCtx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("extension returned status %d", resp.StatusCode)
+	}
+
+	response := &ExtensionHookResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, fmt.Errorf("could not decode extension response: %v", err)
+	}
+
+	return response, nil
+}
+
+func matchesHook(extensionConfig resourcesv1alpha1.ExtensionConfig, hook resourcesv1alpha1.HookType) bool {
+	if len(extensionConfig.Spec.Hooks) == 0 {
+		return true
+	}
+	for _, h := range extensionConfig.Spec.Hooks {
+		if h == hook {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNamespace reports whether the ManagedResource's namespace matches the ExtensionConfig's
+// NamespaceSelector, evaluated against the actual labels of the Namespace object rather than a
+// synthetic stand-in, so that operator-applied namespace labels are honored.
+func (i *ExtensionInvoker) matchesNamespace(ctx context.Context, extensionConfig resourcesv1alpha1.ExtensionConfig, namespace string) (bool, error) {
+	selector := extensionConfig.Spec.NamespaceSelector
+	if selector == nil {
+		return true, nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+
+	ns := &corev1.Namespace{}
+	if err := i.sourceClient.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, fmt.Errorf("could not get namespace %q: %v", namespace, err)
+	}
+
+	return s.Matches(labels.Set(ns.Labels)), nil
+}
+
+func filterByObjectSelector(extensionConfig resourcesv1alpha1.ExtensionConfig, objects []unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+	selector := extensionConfig.Spec.ObjectSelector
+	if selector == nil {
+		return objects, nil
+	}
+
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []unstructured.Unstructured
+	for _, obj := range objects {
+		if s.Matches(labels.Set(obj.GetLabels())) {
+			filtered = append(filtered, obj)
+		}
+	}
+	return filtered, nil
+}
+
+// mergeObjects replaces every object in all that also appears (by GVK/namespace/name/UID) in
+// considered with its possibly mutated or dropped counterpart from response.
+func mergeObjects(all, considered, response []unstructured.Unstructured) []unstructured.Unstructured {
+	replaced := make(map[string]unstructured.Unstructured, len(response))
+	for _, obj := range response {
+		replaced[objectKey(obj)] = obj
+	}
+
+	consideredKeys := make(map[string]bool, len(considered))
+	for _, obj := range considered {
+		consideredKeys[objectKey(obj)] = true
+	}
+
+	merged := make([]unstructured.Unstructured, 0, len(all))
+	for _, obj := range all {
+		key := objectKey(obj)
+		if !consideredKeys[key] {
+			merged = append(merged, obj)
+			continue
+		}
+		if newObj, ok := replaced[key]; ok {
+			merged = append(merged, newObj)
+		}
+		// Object was considered but not returned by the extension: it has been dropped.
+	}
+
+	return merged
+}
+
+func objectKey(obj unstructured.Unstructured) string {
+	gvk := obj.GroupVersionKind()
+	return fmt.Sprintf("%s/%s/%s/%s", gvk.String(), obj.GetNamespace(), obj.GetName(), obj.GetUID())
+}
+
+func failurePolicy(extensionConfig resourcesv1alpha1.ExtensionConfig) resourcesv1alpha1.FailurePolicyType {
+	if extensionConfig.Spec.FailurePolicy == nil {
+		return resourcesv1alpha1.FailurePolicyFail
+	}
+	return *extensionConfig.Spec.FailurePolicy
+}
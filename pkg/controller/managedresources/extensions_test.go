@@ -0,0 +1,206 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMatchesHook(t *testing.T) {
+	any := resourcesv1alpha1.ExtensionConfig{}
+	if !matchesHook(any, resourcesv1alpha1.HookBeforeApply) {
+		t.Errorf("expected an extension with no Hooks set to match every hook")
+	}
+
+	scoped := resourcesv1alpha1.ExtensionConfig{
+		Spec: resourcesv1alpha1.ExtensionConfigSpec{Hooks: []resourcesv1alpha1.HookType{resourcesv1alpha1.HookAfterApply}},
+	}
+	if matchesHook(scoped, resourcesv1alpha1.HookBeforeApply) {
+		t.Errorf("expected an extension scoped to AfterApply not to match BeforeApply")
+	}
+	if !matchesHook(scoped, resourcesv1alpha1.HookAfterApply) {
+		t.Errorf("expected an extension scoped to AfterApply to match AfterApply")
+	}
+}
+
+func TestMatchesNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not build scheme: %v", err)
+	}
+
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}},
+	}
+	invoker := &ExtensionInvoker{sourceClient: fake.NewFakeClientWithScheme(scheme, ns)}
+
+	matching := resourcesv1alpha1.ExtensionConfig{
+		Spec: resourcesv1alpha1.ExtensionConfigSpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+		},
+	}
+	ok, err := invoker.matchesNamespace(context.Background(), matching, "team-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ok {
+		t.Errorf("expected the namespace's actual label to match the selector")
+	}
+
+	notMatching := resourcesv1alpha1.ExtensionConfig{
+		Spec: resourcesv1alpha1.ExtensionConfigSpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+		},
+	}
+	ok, err = invoker.matchesNamespace(context.Background(), notMatching, "team-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok {
+		t.Errorf("expected the namespace's actual label not to match a different selector")
+	}
+}
+
+func TestFilterByObjectSelector(t *testing.T) {
+	labeled := unstructured.Unstructured{}
+	labeled.SetName("labeled")
+	labeled.SetLabels(map[string]string{"kind": "keep"})
+
+	unlabeled := unstructured.Unstructured{}
+	unlabeled.SetName("unlabeled")
+
+	extensionConfig := resourcesv1alpha1.ExtensionConfig{
+		Spec: resourcesv1alpha1.ExtensionConfigSpec{
+			ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kind": "keep"}},
+		},
+	}
+
+	filtered, err := filterByObjectSelector(extensionConfig, []unstructured.Unstructured{labeled, unlabeled})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].GetName() != "labeled" {
+		t.Errorf("expected only the matching object to survive filtering, got %+v", filtered)
+	}
+}
+
+func TestMergeObjects(t *testing.T) {
+	kept := unstructured.Unstructured{}
+	kept.SetName("kept")
+	kept.SetUID("kept-uid")
+
+	mutated := unstructured.Unstructured{}
+	mutated.SetName("mutated")
+	mutated.SetUID("mutated-uid")
+
+	dropped := unstructured.Unstructured{}
+	dropped.SetName("dropped")
+	dropped.SetUID("dropped-uid")
+
+	mutatedResponse := mutated.DeepCopy()
+	mutatedResponse.SetLabels(map[string]string{"mutated": "true"})
+
+	merged := mergeObjects(
+		[]unstructured.Unstructured{kept, mutated, dropped},
+		[]unstructured.Unstructured{mutated, dropped},
+		[]unstructured.Unstructured{*mutatedResponse},
+	)
+
+	if len(merged) != 2 {
+		t.Fatalf("expected the dropped object to be removed, got %+v", merged)
+	}
+	for _, obj := range merged {
+		if obj.GetName() == "dropped" {
+			t.Errorf("expected the dropped object not to appear in the merged result")
+		}
+		if obj.GetName() == "mutated" && obj.GetLabels()["mutated"] != "true" {
+			t.Errorf("expected the considered object to be replaced by its mutated counterpart")
+		}
+	}
+}
+
+func TestInvokeStopsOnRetryAfterSeconds(t *testing.T) {
+	var calls int
+
+	first := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"retryAfterSeconds":5}`))
+	}))
+	defer first.Close()
+
+	second := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{}`))
+	}))
+	defer second.Close()
+
+	scheme := runtime.NewScheme()
+	if err := resourcesv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("could not build scheme: %v", err)
+	}
+
+	extensionConfigList := []runtime.Object{
+		&resourcesv1alpha1.ExtensionConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "first"},
+			Spec:       resourcesv1alpha1.ExtensionConfigSpec{Webhook: resourcesv1alpha1.WebhookClientConfig{URL: first.URL}},
+		},
+		&resourcesv1alpha1.ExtensionConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "second"},
+			Spec:       resourcesv1alpha1.ExtensionConfigSpec{Webhook: resourcesv1alpha1.WebhookClientConfig{URL: second.URL}},
+		},
+	}
+	invoker := &ExtensionInvoker{
+		sourceClient: fake.NewFakeClientWithScheme(scheme, extensionConfigList...),
+		httpClient:   http.DefaultClient,
+	}
+
+	mr := &resourcesv1alpha1.ManagedResource{ObjectMeta: metav1.ObjectMeta{Namespace: "default"}}
+	result, err := invoker.Invoke(context.Background(), resourcesv1alpha1.HookBeforeApply, mr, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.RetryAfter == nil || *result.RetryAfter != 5*time.Second {
+		t.Fatalf("expected RetryAfter to be populated from the first extension's response, got %+v", result.RetryAfter)
+	}
+	if calls != 1 {
+		t.Errorf("expected Invoke to stop after the first extension sets RetryAfterSeconds, got %d calls", calls)
+	}
+}
+
+func TestFailurePolicy(t *testing.T) {
+	if failurePolicy(resourcesv1alpha1.ExtensionConfig{}) != resourcesv1alpha1.FailurePolicyFail {
+		t.Errorf("expected the default FailurePolicy to be Fail")
+	}
+
+	ignore := resourcesv1alpha1.FailurePolicyIgnore
+	withIgnore := resourcesv1alpha1.ExtensionConfig{Spec: resourcesv1alpha1.ExtensionConfigSpec{FailurePolicy: &ignore}}
+	if failurePolicy(withIgnore) != resourcesv1alpha1.FailurePolicyIgnore {
+		t.Errorf("expected an explicit Ignore FailurePolicy to be honored")
+	}
+}
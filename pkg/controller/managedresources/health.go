@@ -0,0 +1,349 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+	"github.com/gardener/gardener-resource-manager/pkg/controller/conditions"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	apiregistrationv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// certificateGroupVersionKind is the GVK of cert-manager's Certificate resource. The resource
+// manager does not vendor cert-manager's API, so its health is inspected via an unstructured
+// object instead of a typed client.
+var certificateGroupVersionKind = fmt.Sprintf("%s, Kind=%s", "certmanager.k8s.io/v1alpha1", "Certificate")
+
+type healthReconciler struct {
+	ctx          context.Context
+	log          logr.Logger
+	scheme       *runtime.Scheme
+	sourceClient client.Client
+	targets      *Targets
+	syncPeriod   time.Duration
+}
+
+// NewHealthReconciler creates a reconciler that periodically re-lists every object a
+// ManagedResource has applied to its target cluster, computes aggregated health for well-known
+// kinds, and surfaces the result as the ResourcesHealthy condition. The target cluster is resolved
+// per ManagedResource via targets and its spec.targetRef.
+func NewHealthReconciler(ctx context.Context, log logr.Logger, scheme *runtime.Scheme, sourceClient client.Client, targets *Targets, syncPeriod time.Duration) reconcile.Reconciler {
+	return &healthReconciler{
+		ctx:          ctx,
+		log:          log,
+		scheme:       scheme,
+		sourceClient: sourceClient,
+		targets:      targets,
+		syncPeriod:   syncPeriod,
+	}
+}
+
+func (r *healthReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	mr := &resourcesv1alpha1.ManagedResource{}
+	if err := r.sourceClient.Get(r.ctx, request.NamespacedName, mr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if !conditions.IsTrue(mr.Status.Conditions, ConditionResourcesApplied) {
+		// Nothing has been applied successfully yet, so there is nothing to check the health of.
+		return reconcile.Result{RequeueAfter: r.syncPeriod}, nil
+	}
+
+	targetClient, err := r.targets.Get(mr.Spec.TargetRef)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not resolve target for ManagedResource %s: %v", request.NamespacedName, err)
+	}
+
+	resources := make([]resourcesv1alpha1.ObjectStatus, len(mr.Status.Resources))
+	unhealthyCount := 0
+
+	for i, resource := range mr.Status.Resources {
+		resources[i] = resource
+		if err := r.checkObjectHealth(targetClient, resource.ObjectReference); err != nil {
+			resources[i].Health = resourcesv1alpha1.ConditionFalse
+			resources[i].Message = err.Error()
+			unhealthyCount++
+		} else {
+			resources[i].Health = resourcesv1alpha1.ConditionTrue
+			resources[i].Message = ""
+		}
+	}
+
+	condition := conditions.GetOrInit(mr.Status.Conditions, ConditionResourcesHealthy)
+	if unhealthyCount == 0 {
+		condition = conditions.Update(condition, resourcesv1alpha1.ConditionTrue, "ResourcesHealthy", "All resources are healthy")
+	} else {
+		condition = conditions.Update(condition, resourcesv1alpha1.ConditionFalse, "ResourcesUnhealthy", fmt.Sprintf("%d of %d resources are unhealthy", unhealthyCount, len(resources)))
+	}
+
+	mr.Status.Conditions = conditions.Set(mr.Status.Conditions, condition)
+	mr.Status.Resources = resources
+
+	if err := r.sourceClient.Status().Update(r.ctx, mr); err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not update health status of ManagedResource %s: %v", request.NamespacedName, err)
+	}
+
+	return reconcile.Result{RequeueAfter: r.syncPeriod}, nil
+}
+
+func (r *healthReconciler) checkObjectHealth(targetClient client.Client, ref resourcesv1alpha1.ObjectReference) error {
+	key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+
+	switch fmt.Sprintf("%s, Kind=%s", ref.APIVersion, ref.Kind) {
+	case "apps/v1, Kind=Deployment":
+		obj := &appsv1.Deployment{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkDeploymentHealth(obj)
+
+	case "apps/v1, Kind=StatefulSet":
+		obj := &appsv1.StatefulSet{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkStatefulSetHealth(obj)
+
+	case "apps/v1, Kind=DaemonSet":
+		obj := &appsv1.DaemonSet{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkDaemonSetHealth(obj)
+
+	case "batch/v1, Kind=Job":
+		obj := &batchv1.Job{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkJobHealth(obj)
+
+	case "v1, Kind=Pod":
+		obj := &corev1.Pod{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkPodHealth(obj)
+
+	case "apiextensions.k8s.io/v1beta1, Kind=CustomResourceDefinition":
+		obj := &apiextensionsv1beta1.CustomResourceDefinition{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkCustomResourceDefinitionHealth(obj)
+
+	case "apiregistration.k8s.io/v1, Kind=APIService":
+		obj := &apiregistrationv1.APIService{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkAPIServiceHealth(obj)
+
+	case "policy/v1beta1, Kind=PodDisruptionBudget":
+		obj := &policyv1beta1.PodDisruptionBudget{}
+		if err := targetClient.Get(r.ctx, key, obj); err != nil {
+			return err
+		}
+		return checkPodDisruptionBudgetHealth(obj)
+
+	case certificateGroupVersionKind:
+		return checkCertificateHealth(r.ctx, targetClient, key)
+
+	default:
+		// Kinds without a dedicated health check are assumed healthy once applied.
+		return nil
+	}
+}
+
+func checkDeploymentHealth(deployment *appsv1.Deployment) error {
+	if deployment.Status.ObservedGeneration < deployment.Generation {
+		return fmt.Errorf("observed generation outdated (%d/%d)", deployment.Status.ObservedGeneration, deployment.Generation)
+	}
+
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse {
+			return fmt.Errorf("deployment %q is not progressing: %s", deployment.Name, condition.Message)
+		}
+		if condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue {
+			return fmt.Errorf("deployment %q has a replica failure: %s", deployment.Name, condition.Message)
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if deployment.Status.UpdatedReplicas < replicas {
+		return fmt.Errorf("deployment %q has not rolled out all replicas (%d/%d updated)", deployment.Name, deployment.Status.UpdatedReplicas, replicas)
+	}
+	if deployment.Status.AvailableReplicas < replicas {
+		return fmt.Errorf("deployment %q has not all replicas available (%d/%d)", deployment.Name, deployment.Status.AvailableReplicas, replicas)
+	}
+
+	return nil
+}
+
+func checkStatefulSetHealth(statefulSet *appsv1.StatefulSet) error {
+	if statefulSet.Status.ObservedGeneration < statefulSet.Generation {
+		return fmt.Errorf("observed generation outdated (%d/%d)", statefulSet.Status.ObservedGeneration, statefulSet.Generation)
+	}
+
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if statefulSet.Status.ReadyReplicas < replicas {
+		return fmt.Errorf("statefulset %q has not all replicas ready (%d/%d)", statefulSet.Name, statefulSet.Status.ReadyReplicas, replicas)
+	}
+	if statefulSet.Status.CurrentRevision != statefulSet.Status.UpdateRevision {
+		return fmt.Errorf("statefulset %q has not finished its rolling update", statefulSet.Name)
+	}
+
+	return nil
+}
+
+func checkDaemonSetHealth(daemonSet *appsv1.DaemonSet) error {
+	if daemonSet.Status.ObservedGeneration < daemonSet.Generation {
+		return fmt.Errorf("observed generation outdated (%d/%d)", daemonSet.Status.ObservedGeneration, daemonSet.Generation)
+	}
+	if daemonSet.Status.NumberUnavailable > 0 {
+		return fmt.Errorf("daemonset %q has %d unavailable pods", daemonSet.Name, daemonSet.Status.NumberUnavailable)
+	}
+	if daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled {
+		return fmt.Errorf("daemonset %q has not rolled out to all scheduled nodes (%d/%d)", daemonSet.Name, daemonSet.Status.UpdatedNumberScheduled, daemonSet.Status.DesiredNumberScheduled)
+	}
+
+	return nil
+}
+
+func checkJobHealth(job *batchv1.Job) error {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
+			return fmt.Errorf("job %q failed: %s", job.Name, condition.Message)
+		}
+	}
+
+	completions := int32(1)
+	if job.Spec.Completions != nil {
+		completions = *job.Spec.Completions
+	}
+	if job.Status.Succeeded < completions {
+		return fmt.Errorf("job %q has not completed yet (%d/%d)", job.Name, job.Status.Succeeded, completions)
+	}
+
+	return nil
+}
+
+func checkPodHealth(pod *corev1.Pod) error {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded, corev1.PodRunning:
+	default:
+		return fmt.Errorf("pod %q is in phase %q", pod.Name, pod.Status.Phase)
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status != corev1.ConditionTrue && pod.Status.Phase != corev1.PodSucceeded {
+			return fmt.Errorf("pod %q is not ready: %s", pod.Name, condition.Message)
+		}
+	}
+
+	return nil
+}
+
+func checkCustomResourceDefinitionHealth(crd *apiextensionsv1beta1.CustomResourceDefinition) error {
+	for _, condition := range crd.Status.Conditions {
+		switch condition.Type {
+		case apiextensionsv1beta1.Established:
+			if condition.Status != apiextensionsv1beta1.ConditionTrue {
+				return fmt.Errorf("CRD %q is not established: %s", crd.Name, condition.Message)
+			}
+		case apiextensionsv1beta1.NamesAccepted:
+			if condition.Status != apiextensionsv1beta1.ConditionTrue {
+				return fmt.Errorf("CRD %q names are not accepted: %s", crd.Name, condition.Message)
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkAPIServiceHealth(apiService *apiregistrationv1.APIService) error {
+	for _, condition := range apiService.Status.Conditions {
+		if condition.Type == apiregistrationv1.Available && condition.Status != apiregistrationv1.ConditionTrue {
+			return fmt.Errorf("APIService %q is not available: %s", apiService.Name, condition.Message)
+		}
+	}
+
+	return nil
+}
+
+func checkPodDisruptionBudgetHealth(pdb *policyv1beta1.PodDisruptionBudget) error {
+	if pdb.Status.ObservedGeneration < pdb.Generation {
+		return fmt.Errorf("observed generation outdated (%d/%d)", pdb.Status.ObservedGeneration, pdb.Generation)
+	}
+	if pdb.Status.CurrentHealthy < pdb.Status.DesiredHealthy {
+		return fmt.Errorf("PodDisruptionBudget %q has fewer healthy pods than desired (%d/%d)", pdb.Name, pdb.Status.CurrentHealthy, pdb.Status.DesiredHealthy)
+	}
+
+	return nil
+}
+
+// checkCertificateHealth inspects a cert-manager Certificate via an unstructured object, since its
+// API is not vendored by the resource manager.
+func checkCertificateHealth(ctx context.Context, targetClient client.Client, key types.NamespacedName) error {
+	cert := &unstructured.Unstructured{}
+	cert.SetAPIVersion("certmanager.k8s.io/v1alpha1")
+	cert.SetKind("Certificate")
+
+	if err := targetClient.Get(ctx, key, cert); err != nil {
+		return err
+	}
+
+	conditions, found, err := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	if err != nil || !found {
+		return fmt.Errorf("certificate %q has no status conditions yet", key.Name)
+	}
+
+	for _, raw := range conditions {
+		condition, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if condition["type"] == "Ready" && condition["status"] != "True" {
+			return fmt.Errorf("certificate %q is not ready: %v", key.Name, condition["message"])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+func TestCheckDeploymentHealth(t *testing.T) {
+	healthy := &appsv1.Deployment{
+		Spec:   appsv1.DeploymentSpec{Replicas: int32Ptr(2)},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 2, AvailableReplicas: 2},
+	}
+	healthy.Generation = 1
+
+	if err := checkDeploymentHealth(healthy); err != nil {
+		t.Errorf("expected healthy deployment, got error: %v", err)
+	}
+
+	outdated := healthy.DeepCopy()
+	outdated.Generation = 2
+	if err := checkDeploymentHealth(outdated); err == nil {
+		t.Errorf("expected error for outdated observed generation")
+	}
+
+	notAvailable := healthy.DeepCopy()
+	notAvailable.Status.AvailableReplicas = 1
+	if err := checkDeploymentHealth(notAvailable); err == nil {
+		t.Errorf("expected error for insufficient available replicas")
+	}
+}
+
+func TestCheckStatefulSetHealth(t *testing.T) {
+	healthy := &appsv1.StatefulSet{
+		Spec:   appsv1.StatefulSetSpec{Replicas: int32Ptr(1)},
+		Status: appsv1.StatefulSetStatus{ObservedGeneration: 1, ReadyReplicas: 1, CurrentRevision: "v1", UpdateRevision: "v1"},
+	}
+	healthy.Generation = 1
+
+	if err := checkStatefulSetHealth(healthy); err != nil {
+		t.Errorf("expected healthy statefulset, got error: %v", err)
+	}
+
+	rollingUpdate := healthy.DeepCopy()
+	rollingUpdate.Status.UpdateRevision = "v2"
+	if err := checkStatefulSetHealth(rollingUpdate); err == nil {
+		t.Errorf("expected error while a rolling update is in progress")
+	}
+}
+
+func TestCheckJobHealth(t *testing.T) {
+	healthy := &batchv1.Job{
+		Spec:   batchv1.JobSpec{Completions: int32Ptr(1)},
+		Status: batchv1.JobStatus{Succeeded: 1},
+	}
+	if err := checkJobHealth(healthy); err != nil {
+		t.Errorf("expected healthy job, got error: %v", err)
+	}
+
+	failed := healthy.DeepCopy()
+	failed.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	if err := checkJobHealth(failed); err == nil {
+		t.Errorf("expected error for a failed job")
+	}
+}
+
+func TestCheckPodHealth(t *testing.T) {
+	running := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	if err := checkPodHealth(running); err != nil {
+		t.Errorf("expected healthy pod, got error: %v", err)
+	}
+
+	failed := &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodFailed}}
+	if err := checkPodHealth(failed); err == nil {
+		t.Errorf("expected error for a failed pod")
+	}
+}
+
+func TestCheckPodDisruptionBudgetHealth(t *testing.T) {
+	healthy := &policyv1beta1.PodDisruptionBudget{
+		Status: policyv1beta1.PodDisruptionBudgetStatus{ObservedGeneration: 1, CurrentHealthy: 2, DesiredHealthy: 2},
+	}
+	healthy.Generation = 1
+
+	if err := checkPodDisruptionBudgetHealth(healthy); err != nil {
+		t.Errorf("expected healthy PodDisruptionBudget, got error: %v", err)
+	}
+
+	unhealthy := healthy.DeepCopy()
+	unhealthy.Status.CurrentHealthy = 1
+	if err := checkPodDisruptionBudgetHealth(unhealthy); err == nil {
+		t.Errorf("expected error for fewer healthy pods than desired")
+	}
+}
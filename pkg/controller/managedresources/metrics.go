@@ -0,0 +1,39 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// applyDurationSeconds observes how long applying a ManagedResource's objects to a target cluster
+// took, labeled by target name so a multi-target deployment can tell its clusters apart.
+var applyDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "resource_manager_apply_duration_seconds",
+	Help:    "Duration of applying a ManagedResource's objects to a target cluster.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"target"})
+
+func init() {
+	metrics.Registry.MustRegister(applyDurationSeconds)
+}
+
+// observeApplyDuration records how long applying objects to the given target took.
+func observeApplyDuration(target string, duration time.Duration) {
+	applyDurationSeconds.WithLabelValues(target).Observe(duration.Seconds())
+}
@@ -0,0 +1,354 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+	"github.com/gardener/gardener-resource-manager/pkg/controller/conditions"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubeyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// managedResourceFinalizer is set on every ManagedResource this reconciler has applied objects
+// for, so the objects are cleaned up (unless KeepObjects is set) before the ManagedResource itself
+// is removed.
+const managedResourceFinalizer = "resources.gardener.cloud/managed-resources-controller"
+
+type reconciler struct {
+	ctx          context.Context
+	log          logr.Logger
+	scheme       *runtime.Scheme
+	sourceClient client.Client
+	targets      *Targets
+	invoker      *ExtensionInvoker
+}
+
+// NewReconciler creates a reconciler that decodes the objects referenced by a ManagedResource's
+// Secrets, applies them to the ManagedResource's target cluster, and reports the outcome as the
+// ResourcesApplied condition. Registered extensions are invoked at the BeforeApply, AfterApply and
+// BeforeDelete hook points via invoker.
+func NewReconciler(ctx context.Context, log logr.Logger, scheme *runtime.Scheme, sourceClient client.Client, targets *Targets, invoker *ExtensionInvoker) reconcile.Reconciler {
+	return &reconciler{
+		ctx:          ctx,
+		log:          log,
+		scheme:       scheme,
+		sourceClient: sourceClient,
+		targets:      targets,
+		invoker:      invoker,
+	}
+}
+
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	mr := &resourcesv1alpha1.ManagedResource{}
+	if err := r.sourceClient.Get(r.ctx, request.NamespacedName, mr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	targetClient, err := r.targets.Get(mr.Spec.TargetRef)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not resolve target for ManagedResource %s: %v", request.NamespacedName, err)
+	}
+
+	if !mr.DeletionTimestamp.IsZero() {
+		return r.delete(mr, targetClient)
+	}
+
+	if err := r.ensureFinalizer(mr); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	objects, err := r.decodeObjects(mr)
+	if err != nil {
+		return r.reportApplyError(mr, err)
+	}
+
+	beforeApplyResult, err := r.invoker.Invoke(r.ctx, resourcesv1alpha1.HookBeforeApply, mr, objects)
+	if err != nil {
+		return r.reportApplyError(mr, err)
+	}
+	if result, handled, err := r.handleHookResult(mr, beforeApplyResult); handled {
+		return result, err
+	}
+	objects = beforeApplyResult.Objects
+
+	start := time.Now()
+	resources, err := r.applyObjects(targetClient, mr, objects)
+	observeApplyDuration(targetName(mr.Spec.TargetRef), time.Since(start))
+	if err != nil {
+		return r.reportApplyError(mr, err)
+	}
+
+	afterApplyResult, err := r.invoker.Invoke(r.ctx, resourcesv1alpha1.HookAfterApply, mr, objects)
+	if err != nil {
+		return r.reportApplyError(mr, err)
+	}
+	if result, handled, err := r.handleHookResult(mr, afterApplyResult); handled {
+		return result, err
+	}
+
+	condition := conditions.GetOrInit(mr.Status.Conditions, ConditionResourcesApplied)
+	condition = conditions.Update(condition, resourcesv1alpha1.ConditionTrue, "ApplySuccessful", "All resources have been applied successfully")
+	mr.Status.Conditions = conditions.Set(mr.Status.Conditions, condition)
+	mr.Status.ObservedGeneration = mr.Generation
+	mr.Status.Resources = resources
+
+	if err := r.sourceClient.Status().Update(r.ctx, mr); err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not update status of ManagedResource %s: %v", request.NamespacedName, err)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// handleHookResult reports whether hookResult asked the reconciliation to stop: if it set
+// RetryAfter or ShortCircuit, the ResourcesApplied condition and status are updated accordingly
+// and handled is true, so the caller should return immediately with the given result and error.
+func (r *reconciler) handleHookResult(mr *resourcesv1alpha1.ManagedResource, hookResult *ExtensionHookResult) (result reconcile.Result, handled bool, err error) {
+	switch {
+	case hookResult.RetryAfter != nil:
+		condition := conditions.GetOrInit(mr.Status.Conditions, ConditionResourcesApplied)
+		condition = conditions.Update(condition, resourcesv1alpha1.ConditionProgressing, "ExtensionRequestedRetry", hookResult.Message)
+		mr.Status.Conditions = conditions.Set(mr.Status.Conditions, condition)
+		if err := r.sourceClient.Status().Update(r.ctx, mr); err != nil {
+			r.log.Error(err, "could not update status after extension requested retry", "managedResource", mr.Name)
+		}
+		return reconcile.Result{RequeueAfter: *hookResult.RetryAfter}, true, nil
+
+	case hookResult.ShortCircuit:
+		condition := conditions.GetOrInit(mr.Status.Conditions, ConditionResourcesApplied)
+		condition = conditions.Update(condition, resourcesv1alpha1.ConditionFalse, "ExtensionShortCircuited", hookResult.Message)
+		mr.Status.Conditions = conditions.Set(mr.Status.Conditions, condition)
+		if err := r.sourceClient.Status().Update(r.ctx, mr); err != nil {
+			r.log.Error(err, "could not update status after extension short-circuited reconciliation", "managedResource", mr.Name)
+		}
+		return reconcile.Result{}, true, nil
+
+	default:
+		return reconcile.Result{}, false, nil
+	}
+}
+
+// objectsFromStatus reconstructs the (possibly incomplete, spec-less) object list a ManagedResource
+// last applied from its status, so it can be handed to the BeforeDelete hook and deleted from the
+// response, the same way the objects decoded from its Secrets are for the apply hooks.
+func objectsFromStatus(resources []resourcesv1alpha1.ObjectStatus) []unstructured.Unstructured {
+	objects := make([]unstructured.Unstructured, 0, len(resources))
+	for _, resource := range resources {
+		obj := unstructured.Unstructured{}
+		obj.SetAPIVersion(resource.APIVersion)
+		obj.SetKind(resource.Kind)
+		obj.SetNamespace(resource.Namespace)
+		obj.SetName(resource.Name)
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+// decodeObjects reads every Secret referenced by mr and decodes each of its data entries as a
+// (possibly multi-document) stream of Kubernetes objects.
+func (r *reconciler) decodeObjects(mr *resourcesv1alpha1.ManagedResource) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+
+	for _, secretRef := range mr.Spec.SecretRefs {
+		secret := &corev1.Secret{}
+		if err := r.sourceClient.Get(r.ctx, client.ObjectKey{Namespace: mr.Namespace, Name: secretRef.Name}, secret); err != nil {
+			return nil, fmt.Errorf("could not get secret %q: %v", secretRef.Name, err)
+		}
+
+		for key, data := range secret.Data {
+			decoder := kubeyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 1024)
+			for {
+				obj := &unstructured.Unstructured{}
+				if err := decoder.Decode(obj); err != nil {
+					if err == io.EOF {
+						break
+					}
+					return nil, fmt.Errorf("could not decode %q in secret %q: %v", key, secretRef.Name, err)
+				}
+				if len(obj.Object) == 0 {
+					continue
+				}
+				objects = append(objects, *obj)
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// applyObjects creates or updates every object on targetClient, honoring mr's
+// ForceOverwriteLabels/ForceOverwriteAnnotations settings for objects that already exist, and
+// returns the resulting ObjectStatus list.
+func (r *reconciler) applyObjects(targetClient client.Client, mr *resourcesv1alpha1.ManagedResource, objects []unstructured.Unstructured) ([]resourcesv1alpha1.ObjectStatus, error) {
+	resources := make([]resourcesv1alpha1.ObjectStatus, 0, len(objects))
+
+	for i := range objects {
+		obj := &objects[i]
+
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(obj.GroupVersionKind())
+		key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+		switch err := targetClient.Get(r.ctx, key, existing); {
+		case apierrors.IsNotFound(err):
+			if err := targetClient.Create(r.ctx, obj); err != nil {
+				return nil, fmt.Errorf("could not create %s %s/%s: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+			}
+
+		case err != nil:
+			return nil, fmt.Errorf("could not get %s %s/%s: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+
+		default:
+			mergeLabelsAndAnnotations(existing, obj, mr)
+			obj.SetResourceVersion(existing.GetResourceVersion())
+			if err := targetClient.Update(r.ctx, obj); err != nil {
+				return nil, fmt.Errorf("could not update %s %s/%s: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+			}
+		}
+
+		resources = append(resources, resourcesv1alpha1.ObjectStatus{
+			ObjectReference: resourcesv1alpha1.ObjectReference{
+				APIVersion: obj.GetAPIVersion(),
+				Kind:       obj.GetKind(),
+				Namespace:  obj.GetNamespace(),
+				Name:       obj.GetName(),
+			},
+			Health: resourcesv1alpha1.ConditionProgressing,
+		})
+	}
+
+	return resources, nil
+}
+
+// mergeLabelsAndAnnotations merges desired's labels and annotations into existing's, overwriting
+// conflicting keys only if the corresponding ForceOverwrite* flag is set, and stores the result
+// back on desired so it is carried over by the subsequent update.
+func mergeLabelsAndAnnotations(existing, desired *unstructured.Unstructured, mr *resourcesv1alpha1.ManagedResource) {
+	overwriteLabels := mr.Spec.ForceOverwriteLabels != nil && *mr.Spec.ForceOverwriteLabels
+	desired.SetLabels(mergedKeys(existing.GetLabels(), desired.GetLabels(), overwriteLabels))
+
+	overwriteAnnotations := mr.Spec.ForceOverwriteAnnotations != nil && *mr.Spec.ForceOverwriteAnnotations
+	desired.SetAnnotations(mergedKeys(existing.GetAnnotations(), desired.GetAnnotations(), overwriteAnnotations))
+}
+
+func mergedKeys(existing, desired map[string]string, overwrite bool) map[string]string {
+	merged := make(map[string]string, len(existing)+len(desired))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range desired {
+		if overwrite {
+			merged[k] = v
+			continue
+		}
+		if _, ok := merged[k]; !ok {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// delete removes the objects a ManagedResource has applied (unless KeepObjects is set) and drops
+// its finalizer, so the ManagedResource itself can be garbage collected.
+func (r *reconciler) delete(mr *resourcesv1alpha1.ManagedResource, targetClient client.Client) (reconcile.Result, error) {
+	if !containsFinalizer(mr.Finalizers, managedResourceFinalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	if mr.Spec.KeepObjects == nil || !*mr.Spec.KeepObjects {
+		beforeDeleteResult, err := r.invoker.Invoke(r.ctx, resourcesv1alpha1.HookBeforeDelete, mr, objectsFromStatus(mr.Status.Resources))
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if result, handled, err := r.handleHookResult(mr, beforeDeleteResult); handled {
+			return result, err
+		}
+
+		for i := range beforeDeleteResult.Objects {
+			obj := &beforeDeleteResult.Objects[i]
+
+			if err := targetClient.Delete(r.ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+				return reconcile.Result{}, fmt.Errorf("could not delete %s %s/%s: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+			}
+		}
+	}
+
+	mr.Finalizers = removeFinalizer(mr.Finalizers, managedResourceFinalizer)
+	if err := r.sourceClient.Update(r.ctx, mr); err != nil {
+		return reconcile.Result{}, fmt.Errorf("could not remove finalizer from ManagedResource %s/%s: %v", mr.Namespace, mr.Name, err)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+func (r *reconciler) ensureFinalizer(mr *resourcesv1alpha1.ManagedResource) error {
+	if containsFinalizer(mr.Finalizers, managedResourceFinalizer) {
+		return nil
+	}
+
+	mr.Finalizers = append(mr.Finalizers, managedResourceFinalizer)
+	if err := r.sourceClient.Update(r.ctx, mr); err != nil {
+		return fmt.Errorf("could not add finalizer to ManagedResource %s/%s: %v", mr.Namespace, mr.Name, err)
+	}
+	return nil
+}
+
+// reportApplyError records applyErr as the reason the ResourcesApplied condition is False and
+// returns it so the reconciliation is retried with backoff.
+func (r *reconciler) reportApplyError(mr *resourcesv1alpha1.ManagedResource, applyErr error) (reconcile.Result, error) {
+	condition := conditions.GetOrInit(mr.Status.Conditions, ConditionResourcesApplied)
+	condition = conditions.Update(condition, resourcesv1alpha1.ConditionFalse, "ApplyFailed", applyErr.Error())
+	mr.Status.Conditions = conditions.Set(mr.Status.Conditions, condition)
+
+	if err := r.sourceClient.Status().Update(r.ctx, mr); err != nil {
+		r.log.Error(err, "could not update status after apply error", "managedResource", mr.Name)
+	}
+
+	return reconcile.Result{}, applyErr
+}
+
+func containsFinalizer(finalizers []string, finalizer string) bool {
+	for _, f := range finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeFinalizer(finalizers []string, finalizer string) []string {
+	result := make([]string, 0, len(finalizers))
+	for _, f := range finalizers {
+		if f != finalizer {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InClusterTargetName is the reserved name of the pseudo-target that talks to the cluster the
+// resource-manager itself runs in, so a single deployment can manage both the seed and one or
+// more shoots without a dedicated kubeconfig entry for the seed.
+const InClusterTargetName = "in-cluster"
+
+// Targets holds one client.Client per target cluster a ManagedResource may address via
+// spec.targetRef.name, keyed by target name. Every registered client is expected to be
+// cache-backed for reads (see the cmd/app package's client construction), since the apply and
+// health reconcilers look up the same objects repeatedly across many ManagedResources. Targets is
+// safe for concurrent use: targets may be added, replaced or removed at runtime, e.g. by a
+// kubeconfig directory watch, while reconciliations backed by previously resolved clients are in
+// flight.
+type Targets struct {
+	mu      sync.RWMutex
+	clients map[string]client.Client
+	stops   map[string]context.CancelFunc
+}
+
+// NewTargets creates an empty Targets registry.
+func NewTargets() *Targets {
+	return &Targets{
+		clients: map[string]client.Client{},
+		stops:   map[string]context.CancelFunc{},
+	}
+}
+
+// Set registers or replaces the client for the given target name. stop, if non-nil, is called once
+// the target is later replaced or removed, so the cache and informers backing a previous client
+// for the same name are torn down instead of leaking; pass nil for a client whose lifecycle is
+// owned elsewhere (e.g. the manager's own in-cluster client).
+func (t *Targets) Set(name string, c client.Client, stop context.CancelFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if previousStop, ok := t.stops[name]; ok && previousStop != nil {
+		previousStop()
+	}
+
+	t.clients[name] = c
+	t.stops[name] = stop
+}
+
+// Remove deregisters the client for the given target name, if any, and stops it.
+func (t *Targets) Remove(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if stop, ok := t.stops[name]; ok && stop != nil {
+		stop()
+	}
+
+	delete(t.clients, name)
+	delete(t.stops, name)
+}
+
+// Names returns the names of all currently registered targets.
+func (t *Targets) Names() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names := make([]string, 0, len(t.clients))
+	for name := range t.clients {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Get resolves the client for the target referenced by ref, defaulting to the in-cluster
+// pseudo-target if ref is nil or its name is empty. A name that is not registered is an error.
+func (t *Targets) Get(ref *resourcesv1alpha1.TargetRef) (client.Client, error) {
+	name := targetName(ref)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	c, ok := t.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown target %q", name)
+	}
+	return c, nil
+}
+
+// targetName returns the name ref resolves to, defaulting to the in-cluster pseudo-target.
+func targetName(ref *resourcesv1alpha1.TargetRef) string {
+	if ref != nil && len(ref.Name) > 0 {
+		return ref.Name
+	}
+	return InClusterTargetName
+}
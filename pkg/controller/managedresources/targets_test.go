@@ -0,0 +1,75 @@
+// Copyright (c) 2019 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package managedresources
+
+import (
+	"testing"
+
+	resourcesv1alpha1 "github.com/gardener/gardener-resource-manager/pkg/apis/resources/v1alpha1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTargetsGetDefaultsToInCluster(t *testing.T) {
+	targets := NewTargets()
+	inCluster := fake.NewFakeClient()
+	targets.Set(InClusterTargetName, inCluster, nil)
+
+	c, err := targets.Get(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c != inCluster {
+		t.Errorf("expected a nil TargetRef to resolve to the in-cluster client")
+	}
+
+	c, err = targets.Get(&resourcesv1alpha1.TargetRef{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c != inCluster {
+		t.Errorf("expected an empty TargetRef name to resolve to the in-cluster client")
+	}
+}
+
+func TestTargetsSetGetRemove(t *testing.T) {
+	targets := NewTargets()
+	shoot := fake.NewFakeClient()
+	targets.Set("shoot-a", shoot, nil)
+
+	c, err := targets.Get(&resourcesv1alpha1.TargetRef{Name: "shoot-a"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c != shoot {
+		t.Errorf("expected the registered client to be returned")
+	}
+
+	if names := targets.Names(); len(names) != 1 || names[0] != "shoot-a" {
+		t.Errorf("expected Names to report the registered target, got %v", names)
+	}
+
+	targets.Remove("shoot-a")
+	if _, err := targets.Get(&resourcesv1alpha1.TargetRef{Name: "shoot-a"}); err == nil {
+		t.Errorf("expected an error after removing the target")
+	}
+}
+
+func TestTargetsGetUnknown(t *testing.T) {
+	targets := NewTargets()
+	if _, err := targets.Get(&resourcesv1alpha1.TargetRef{Name: "nope"}); err == nil {
+		t.Errorf("expected an error for an unregistered target")
+	}
+}